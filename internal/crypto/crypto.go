@@ -0,0 +1,56 @@
+// Package crypto provides the small AES-256-GCM envelope shared by ollama's
+// at-rest encryption features -- encrypted manifests and encrypted history --
+// so both seal data the same way instead of each hand-rolling the primitive.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+)
+
+// ErrTruncated is returned by Open when sealed is too short to contain a
+// nonce, meaning it can't have come from Seal.
+var ErrTruncated = errors.New("crypto: ciphertext is truncated")
+
+// Seal AES-256-GCM encrypts plaintext under key, which must be 32 bytes, and
+// prepends the freshly generated nonce to the returned ciphertext.
+func Seal(plaintext, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open reverses Seal, splitting the leading nonce back off of sealed before
+// decrypting under key.
+func Open(sealed, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrTruncated
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
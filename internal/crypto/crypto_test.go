@@ -0,0 +1,52 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("failed to generate test key: %v", err)
+	}
+	return key
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	key := testKey(t)
+	plaintext := []byte("ollama manifest body")
+
+	sealed, err := Seal(plaintext, key)
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	opened, err := Open(sealed, key)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	if !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open() = %q, want %q", opened, plaintext)
+	}
+}
+
+func TestOpenWrongKey(t *testing.T) {
+	sealed, err := Seal([]byte("secret"), testKey(t))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	if _, err := Open(sealed, testKey(t)); err == nil {
+		t.Fatal("Open() with the wrong key succeeded, want an error")
+	}
+}
+
+func TestOpenTruncated(t *testing.T) {
+	if _, err := Open([]byte("short"), testKey(t)); err != ErrTruncated {
+		t.Fatalf("Open() error = %v, want ErrTruncated", err)
+	}
+}
@@ -0,0 +1,47 @@
+// Package logging constructs the process-wide slog.Logger used by ollama's
+// internal packages, so that diagnostic output goes through one configurable
+// handler instead of being scattered across ad-hoc fmt.Print calls.
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	once   sync.Once
+	logger *slog.Logger
+)
+
+// Logger returns the package-level slog.Logger, building it on first use from
+// OLLAMA_LOG_LEVEL (debug, info, warn, error; default info) and
+// OLLAMA_LOG_FORMAT (json selects the JSON handler; anything else is text).
+func Logger() *slog.Logger {
+	once.Do(func() {
+		logger = slog.New(newHandler())
+	})
+	return logger
+}
+
+func newHandler() slog.Handler {
+	opts := &slog.HandlerOptions{Level: level()}
+	if strings.EqualFold(os.Getenv("OLLAMA_LOG_FORMAT"), "json") {
+		return slog.NewJSONHandler(os.Stderr, opts)
+	}
+	return slog.NewTextHandler(os.Stderr, opts)
+}
+
+func level() slog.Level {
+	switch strings.ToLower(os.Getenv("OLLAMA_LOG_LEVEL")) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
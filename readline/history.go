@@ -1,185 +1,144 @@
 package readline
 
 import (
-	"bufio"
-	"errors"
-	"fmt"
-	"io"
+	"log/slog"
 	"os"
-	"path/filepath"
 	"strings"
 
-	"github.com/emirpasic/gods/v2/lists/arraylist"
+	"github.com/ollama/ollama/internal/logging"
 )
 
-type History struct {
-	Buf      *arraylist.List[string]
-	Autosave bool
-	Pos      int
-	Limit    int
-	Filename string
-	Enabled  bool
-}
+var logger = logging.Logger()
 
-func NewHistory() (*History, error) {
-	fmt.Println("Creating new history instance")
-	h := &History{
-		Buf:      arraylist.New[string](),
-		Limit:    100, // resizeme
-		Autosave: true,
-		Enabled:  true,
-	}
+// SearchMode selects how History.Search matches query against stored entries.
+type SearchMode int
 
-	err := h.Init()
-	if err != nil {
-		fmt.Printf("Error initializing history: %v\n", err)
-		return nil, err
-	}
+const (
+	// SearchSubstring matches query as a literal, case-sensitive substring.
+	SearchSubstring SearchMode = iota
+	// SearchCaseInsensitive matches query as a case-insensitive substring.
+	SearchCaseInsensitive
+	// SearchFuzzy scores entries as a fuzzy subsequence match.
+	SearchFuzzy
+)
 
-	fmt.Printf("History initialized successfully. Current size: %d\n", h.Size())
-	return h, nil
+// SearchHit is a single History.Search result.
+type SearchHit struct {
+	Text      string
+	Positions []int
+	Score     float64
 }
 
-func (h *History) Init() error {
-	fmt.Println("Initializing history")
-	home, err := os.UserHomeDir()
-	if err != nil {
-		fmt.Printf("Error getting user home directory: %v\n", err)
-		return err
-	}
+// HistoryStore is the persistence and navigation backend for command history.
+// The file-backed, SQLite, and encrypted-file drivers all implement it so
+// History can swap backends via OLLAMA_HISTORY_BACKEND without callers
+// changing.
+type HistoryStore interface {
+	Add(s string)
+	Prev() string
+	Next() string
+	Search(query string, mode SearchMode) []SearchHit
+	Clear()
+	Save() error
+	Load() error
+	// Size reports the number of entries currently held by the store.
+	Size() int
+	// SetEnabled toggles whether new entries are persisted; entries still
+	// flow through Add/AddEntry for in-session recall either way.
+	SetEnabled(enabled bool)
+}
 
-	path := filepath.Join(home, ".ollama", "history")
-	fmt.Printf("History file path: %s\n", path)
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		fmt.Printf("Error creating history directory: %v\n", err)
-		return err
-	}
+// EntryStore is implemented by HistoryStore drivers that can persist
+// structured Entry records (timestamp, session, model, duration) and enforce
+// a HistoryPolicy, rather than bare strings. The file-backed and SQLite
+// drivers both implement it.
+type EntryStore interface {
+	AddEntry(e Entry)
+	SetPolicy(p HistoryPolicy)
+}
 
-	h.Filename = path
+// History is the readline-facing handle for command history; it delegates to
+// a pluggable HistoryStore so the on-disk representation can change without
+// affecting callers.
+type History struct {
+	Store HistoryStore
+}
 
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+// NewHistory constructs a History backed by the driver named in
+// OLLAMA_HISTORY_BACKEND (file, sqlite, encrypted; default file).
+func NewHistory() (*History, error) {
+	store, err := newStoreFromEnv()
 	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			fmt.Println("History file doesn't exist yet - will create new one")
-			return nil
-		}
-		fmt.Printf("Error opening history file: %v\n", err)
-		return err
-	}
-	defer f.Close()
-
-	fmt.Println("Reading existing history file")
-	r := bufio.NewReader(f)
-	for {
-		line, err := r.ReadString('\n')
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				fmt.Println("Finished reading history file")
-				break
-			}
-			fmt.Printf("Error reading history file: %v\n", err)
-			return err
-		}
-
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
-			fmt.Println("Skipping empty line in history")
-			continue
-		}
-
-		fmt.Printf("Adding line to history: %s\n", line)
-		h.Add(line)
+		logger.Error("failed to construct history store", "error", err)
+		return nil, err
 	}
 
-	return nil
+	return NewHistoryWithStore(store)
 }
 
-func (h *History) Add(s string) {
-	fmt.Printf("Adding new entry to history: %s\n", s)
-	h.Buf.Add(s)
-	h.Compact()
-	h.Pos = h.Size()
-	if h.Autosave {
-		fmt.Println("Autosave enabled - saving history")
-		_ = h.Save()
+// NewHistoryWithStore constructs a History around an already-configured
+// HistoryStore, loading any existing entries.
+func NewHistoryWithStore(store HistoryStore) (*History, error) {
+	h := &History{Store: store}
+	if err := h.Store.Load(); err != nil {
+		logger.Error("failed to load history store", "error", err)
+		return nil, err
 	}
+
+	return h, nil
 }
 
-func (h *History) Compact() {
-	s := h.Buf.Size()
-	if s > h.Limit {
-		fmt.Printf("Compacting history - current size %d exceeds limit %d\n", s, h.Limit)
-		for range s - h.Limit {
-			h.Buf.Remove(0)
-		}
-		fmt.Printf("History compacted - new size: %d\n", h.Buf.Size())
+func newStoreFromEnv() (HistoryStore, error) {
+	switch backend := strings.ToLower(os.Getenv("OLLAMA_HISTORY_BACKEND")); backend {
+	case "", "file":
+		return newFileHistoryStore("")
+	case "sqlite":
+		return newSQLiteHistoryStore("")
+	case "encrypted":
+		return newEncryptedFileHistoryStore("")
+	default:
+		logger.Warn("unknown OLLAMA_HISTORY_BACKEND, falling back to file", slog.String("backend", backend))
+		return newFileHistoryStore("")
 	}
 }
 
-func (h *History) Clear() {
-	fmt.Println("Clearing history")
-	h.Buf.Clear()
-}
+func (h *History) Add(s string) { h.Store.Add(s) }
 
-func (h *History) Prev() (line string) {
-	fmt.Printf("Getting previous history entry (current pos: %d)\n", h.Pos)
-	if h.Pos > 0 {
-		h.Pos -= 1
+// AddEntry records e with its full metadata on a Store that implements
+// EntryStore, or falls back to a plain Add(e.Text) otherwise.
+func (h *History) AddEntry(e Entry) {
+	if es, ok := h.Store.(EntryStore); ok {
+		es.AddEntry(e)
+		return
 	}
-	line, _ = h.Buf.Get(h.Pos)
-	fmt.Printf("Returning history entry: %s (new pos: %d)\n", line, h.Pos)
-	return line
+	h.Store.Add(e.Text)
 }
 
-func (h *History) Next() (line string) {
-	fmt.Printf("Getting next history entry (current pos: %d)\n", h.Pos)
-	if h.Pos < h.Buf.Size() {
-		h.Pos += 1
-		line, _ = h.Buf.Get(h.Pos)
-		fmt.Printf("Returning history entry: %s (new pos: %d)\n", line, h.Pos)
-	} else {
-		fmt.Println("Already at newest history position")
+// SetPolicy applies p to a Store that implements EntryStore; it's a no-op on
+// drivers that don't support HistoryPolicy.
+func (h *History) SetPolicy(p HistoryPolicy) {
+	if es, ok := h.Store.(EntryStore); ok {
+		es.SetPolicy(p)
 	}
-	return line
 }
 
-func (h *History) Size() int {
-	size := h.Buf.Size()
-	fmt.Printf("Getting history size: %d\n", size)
-	return size
-}
+func (h *History) Prev() string { return h.Store.Prev() }
 
-func (h *History) Save() error {
-	if !h.Enabled {
-		fmt.Println("History disabled - not saving")
-		return nil
-	}
+func (h *History) Next() string { return h.Store.Next() }
 
-	fmt.Println("Saving history to file")
-	tmpFile := h.Filename + ".tmp"
-	fmt.Printf("Using temp file: %s\n", tmpFile)
+func (h *History) Search(query string, mode SearchMode) []SearchHit {
+	return h.Store.Search(query, mode)
+}
 
-	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0o600)
-	if err != nil {
-		fmt.Printf("Error opening temp file for writing: %v\n", err)
-		return err
-	}
-	defer f.Close()
+func (h *History) Clear() { h.Store.Clear() }
 
-	buf := bufio.NewWriter(f)
-	for cnt := range h.Size() {
-		line, _ := h.Buf.Get(cnt)
-		fmt.Fprintln(buf, line)
-	}
-	buf.Flush()
-	f.Close()
+func (h *History) Save() error { return h.Store.Save() }
 
-	fmt.Printf("Renaming temp file to %s\n", h.Filename)
-	if err = os.Rename(tmpFile, h.Filename); err != nil {
-		fmt.Printf("Error renaming temp file: %v\n", err)
-		return err
-	}
+func (h *History) Load() error { return h.Store.Load() }
 
-	fmt.Println("History saved successfully")
-	return nil
-}
+// Size returns the number of entries currently held by the underlying store.
+func (h *History) Size() int { return h.Store.Size() }
+
+// SetEnabled toggles whether new entries are persisted by the underlying
+// store.
+func (h *History) SetEnabled(enabled bool) { h.Store.SetEnabled(enabled) }
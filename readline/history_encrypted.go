@@ -0,0 +1,183 @@
+package readline
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/ollama/ollama/internal/crypto"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	historyKeyringService = "ollama"
+	historyKeyringUser    = "history"
+)
+
+// encryptedFileHistoryStore wraps the flat-file driver but AES-GCM encrypts
+// the whole file at rest, keyed from $OLLAMA_HISTORY_KEY or, failing that, a
+// key generated on first use and stashed in the OS keyring.
+type encryptedFileHistoryStore struct {
+	*fileHistoryStore
+	key []byte
+}
+
+// newEncryptedFileHistoryStore constructs an encrypted file-backed store. An
+// empty path resolves to $HOME/.ollama/history, same as the plain driver.
+func newEncryptedFileHistoryStore(path string) (*encryptedFileHistoryStore, error) {
+	inner, err := newFileHistoryStore(path)
+	if err != nil {
+		return nil, err
+	}
+	// Autosave would otherwise write plaintext via fileHistoryStore.Save;
+	// Save is driven explicitly from Add below instead.
+	inner.Autosave = false
+
+	key, err := historyEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedFileHistoryStore{fileHistoryStore: inner, key: key}, nil
+}
+
+func historyEncryptionKey() ([]byte, error) {
+	if s := os.Getenv("OLLAMA_HISTORY_KEY"); s != "" {
+		key, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OLLAMA_HISTORY_KEY: %w", err)
+		}
+		if len(key) != 32 {
+			return nil, errors.New("OLLAMA_HISTORY_KEY must decode to 32 bytes")
+		}
+		return key, nil
+	}
+
+	s, err := keyring.Get(historyKeyringService, historyKeyringUser)
+	if err == nil {
+		return hex.DecodeString(s)
+	}
+	if !errors.Is(err, keyring.ErrNotFound) {
+		return nil, err
+	}
+
+	logger.Debug("generating new history encryption key in OS keyring")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := keyring.Set(historyKeyringService, historyKeyringUser, hex.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *encryptedFileHistoryStore) Add(line string) {
+	s.fileHistoryStore.Add(line)
+	if err := s.Save(); err != nil {
+		logger.Error("failed to save encrypted history", "error", err)
+	}
+}
+
+func (s *encryptedFileHistoryStore) AddEntry(e Entry) {
+	s.fileHistoryStore.AddEntry(e)
+	if err := s.Save(); err != nil {
+		logger.Error("failed to save encrypted history", "error", err)
+	}
+}
+
+func (s *encryptedFileHistoryStore) Load() error {
+	logger.Debug("loading encrypted history", slog.String("path", s.Filename))
+	if err := s.loadPolicy(); err != nil {
+		logger.Error("failed to load history policy", "error", err)
+		return err
+	}
+
+	raw, err := os.ReadFile(s.Filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Debug("encrypted history file does not exist yet, will create new one")
+			return nil
+		}
+		logger.Error("failed to read encrypted history file", "error", err)
+		return err
+	}
+
+	entries, err := s.decrypt(raw)
+	if err != nil {
+		logger.Error("failed to decrypt history file", "error", err)
+		return err
+	}
+
+	for _, e := range entries {
+		s.fileHistoryStore.appendEntry(e)
+	}
+
+	return nil
+}
+
+func (s *encryptedFileHistoryStore) Save() error {
+	if !s.Enabled {
+		logger.Debug("history disabled, not saving")
+		return nil
+	}
+
+	sealed, err := s.encrypt(s.Entries)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.Filename + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0o600); err != nil {
+		logger.Error("failed to write temp encrypted history file", "error", err)
+		return err
+	}
+
+	if err := os.Rename(tmp, s.Filename); err != nil {
+		logger.Error("failed to rename temp encrypted history file", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// encrypt seals entries as a JSON array, preserving every Entry field
+// (Timestamp, SessionID, Model, Duration), not just Text.
+func (s *encryptedFileHistoryStore) encrypt(entries []Entry) ([]byte, error) {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Seal(body, s.key)
+}
+
+// decrypt reverses encrypt. It also accepts a plain []string body, so an
+// encrypted history file written before entries carried metadata still
+// loads -- each string becomes an Entry with only Text set.
+func (s *encryptedFileHistoryStore) decrypt(sealed []byte) ([]Entry, error) {
+	body, err := crypto.Open(sealed, s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(body, &entries); err == nil {
+		return entries, nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(body, &lines); err != nil {
+		return nil, err
+	}
+
+	entries = make([]Entry, len(lines))
+	for i, line := range lines {
+		entries[i] = Entry{Text: line}
+	}
+	return entries, nil
+}
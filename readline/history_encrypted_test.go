@@ -0,0 +1,71 @@
+package readline
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testHistoryKeyA = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+	testHistoryKeyB = "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f"
+)
+
+func TestEncryptedFileHistoryStoreSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	t.Setenv("OLLAMA_HISTORY_KEY", testHistoryKeyA)
+
+	s, err := newEncryptedFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newEncryptedFileHistoryStore() error = %v", err)
+	}
+	s.AddEntry(Entry{
+		Text:      "ollama run llama3",
+		SessionID: "abc",
+		Model:     "llama3",
+		Duration:  5 * time.Second,
+	})
+
+	reloaded, err := newEncryptedFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newEncryptedFileHistoryStore() error = %v", err)
+	}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(reloaded.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(reloaded.Entries))
+	}
+	got := reloaded.Entries[0]
+	if got.Text != "ollama run llama3" || got.SessionID != "abc" || got.Model != "llama3" || got.Duration != 5*time.Second {
+		t.Fatalf("reloaded entry = %+v, want Text/SessionID/Model/Duration preserved", got)
+	}
+}
+
+func TestEncryptedFileHistoryStoreWrongKeyFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	t.Setenv("OLLAMA_HISTORY_KEY", testHistoryKeyA)
+
+	s, err := newEncryptedFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newEncryptedFileHistoryStore() error = %v", err)
+	}
+	s.AddEntry(Entry{Text: "ollama run llama3"})
+
+	t.Setenv("OLLAMA_HISTORY_KEY", testHistoryKeyB)
+	reloaded, err := newEncryptedFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newEncryptedFileHistoryStore() error = %v", err)
+	}
+	if err := reloaded.Load(); err == nil {
+		t.Fatal("Load() with wrong OLLAMA_HISTORY_KEY succeeded, want error")
+	}
+}
+
+func TestEncryptedFileHistoryStoreInvalidKeyFails(t *testing.T) {
+	t.Setenv("OLLAMA_HISTORY_KEY", "not-valid-hex")
+	if _, err := newEncryptedFileHistoryStore(filepath.Join(t.TempDir(), "history")); err == nil {
+		t.Fatal("newEncryptedFileHistoryStore() with invalid OLLAMA_HISTORY_KEY succeeded, want error")
+	}
+}
@@ -0,0 +1,27 @@
+package readline
+
+import "time"
+
+// Entry is a single structured history record. It's serialized one-per-line
+// as JSON by the file-backed store; plain-text history files (one bare
+// command per line, no metadata) are still readable, entry-per-line, on load.
+type Entry struct {
+	Text      string        `json:"text"`
+	Timestamp time.Time     `json:"timestamp"`
+	SessionID string        `json:"session_id,omitempty"`
+	Model     string        `json:"model,omitempty"`
+	Duration  time.Duration `json:"duration,omitempty"`
+}
+
+// HistoryPolicy controls which entries Add keeps, mirroring bash's
+// HISTCONTROL options.
+type HistoryPolicy struct {
+	// IgnoreDups skips an entry identical to the immediately preceding one.
+	IgnoreDups bool
+	// IgnoreSpace skips an entry that starts with whitespace.
+	IgnoreSpace bool
+	// EraseDups removes every earlier entry with the same text before
+	// appending the new one, so a command only ever appears once, most
+	// recently.
+	EraseDups bool
+}
@@ -0,0 +1,302 @@
+package readline
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// fileHistoryStore is the original flat-file HistoryStore driver: one
+// structured Entry per line, persisted as JSON to $HOME/.ollama/history.
+// Older plain-text history files (one bare command per line, no metadata)
+// are still readable -- any line that doesn't parse as an Entry is treated
+// as one with only Text set. The active HistoryPolicy is persisted
+// alongside the history file in a sidecar history.conf.
+type fileHistoryStore struct {
+	Entries  []Entry
+	Autosave bool
+	Pos      int
+	Limit    int
+	Filename string
+	Enabled  bool
+	policy   HistoryPolicy
+}
+
+// newFileHistoryStore constructs a file-backed store. An empty path resolves
+// to $HOME/.ollama/history.
+func newFileHistoryStore(path string) (*fileHistoryStore, error) {
+	logger.Debug("creating new file history store")
+	s := &fileHistoryStore{
+		Limit:    100, // resizeme
+		Autosave: true,
+		Enabled:  true,
+		Filename: path,
+	}
+
+	if s.Filename == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			logger.Error("failed to get user home directory", "error", err)
+			return nil, err
+		}
+		s.Filename = filepath.Join(home, ".ollama", "history")
+	}
+
+	return s, nil
+}
+
+// policyPath is the sidecar file the active HistoryPolicy is persisted to,
+// alongside the history file itself.
+func (s *fileHistoryStore) policyPath() string {
+	return filepath.Join(filepath.Dir(s.Filename), "history.conf")
+}
+
+// SetPolicy replaces the active HistoryPolicy and persists it to the sidecar
+// config file so it survives across sessions.
+func (s *fileHistoryStore) SetPolicy(p HistoryPolicy) {
+	s.policy = p
+	if err := s.savePolicy(); err != nil {
+		logger.Error("failed to save history policy", "error", err)
+	}
+}
+
+func (s *fileHistoryStore) savePolicy() error {
+	f, err := os.OpenFile(s.policyPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(s.policy)
+}
+
+func (s *fileHistoryStore) loadPolicy() error {
+	f, err := os.Open(s.policyPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&s.policy); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileHistoryStore) Load() error {
+	logger.Debug("initializing history", slog.String("path", s.Filename))
+	if err := os.MkdirAll(filepath.Dir(s.Filename), 0o755); err != nil {
+		logger.Error("failed to create history directory", "error", err)
+		return err
+	}
+
+	if err := s.loadPolicy(); err != nil {
+		logger.Error("failed to load history policy", "error", err)
+		return err
+	}
+
+	f, err := os.OpenFile(s.Filename, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Debug("history file does not exist yet, will create new one", slog.String("path", s.Filename))
+			return nil
+		}
+		logger.Error("failed to open history file", slog.String("path", s.Filename), "error", err)
+		return err
+	}
+	defer f.Close()
+
+	logger.Debug("reading existing history file", slog.String("path", s.Filename))
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				logger.Debug("finished reading history file")
+				break
+			}
+			logger.Error("failed to read history file", "error", err)
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			logger.Debug("skipping empty line in history")
+			continue
+		}
+
+		logger.Debug("adding line to history", slog.String("line", line))
+		s.appendEntry(parseHistoryLine(line))
+	}
+
+	return nil
+}
+
+// parseHistoryLine decodes one line of the history file as an Entry, falling
+// back to a bare Entry{Text: line} when the line predates structured history
+// and isn't valid JSON.
+func parseHistoryLine(line string) Entry {
+	var e Entry
+	if err := json.Unmarshal([]byte(line), &e); err == nil && e.Text != "" {
+		return e
+	}
+	return Entry{Text: line}
+}
+
+// Add appends a plain-text entry, stamping it with the current time. It's
+// the HistoryStore.Add path used by callers that don't have richer metadata;
+// AddEntry is used when session/model/duration are known.
+func (s *fileHistoryStore) Add(line string) {
+	s.AddEntry(Entry{Text: line})
+}
+
+// AddEntry appends e, applying the active HistoryPolicy (IgnoreSpace,
+// IgnoreDups, EraseDups) the same way bash's HISTCONTROL does.
+func (s *fileHistoryStore) AddEntry(e Entry) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	if s.policy.IgnoreSpace && strings.TrimLeftFunc(e.Text, unicode.IsSpace) != e.Text {
+		logger.Debug("ignoring entry with leading whitespace per policy")
+		return
+	}
+
+	if s.policy.IgnoreDups && len(s.Entries) > 0 && s.Entries[len(s.Entries)-1].Text == e.Text {
+		logger.Debug("ignoring duplicate entry per policy")
+		return
+	}
+
+	if s.policy.EraseDups {
+		kept := s.Entries[:0]
+		for _, existing := range s.Entries {
+			if existing.Text != e.Text {
+				kept = append(kept, existing)
+			}
+		}
+		s.Entries = kept
+	}
+
+	logger.Debug("adding new entry to history", slog.String("entry", e.Text))
+	s.appendEntry(e)
+	if s.Autosave {
+		logger.Debug("autosave enabled, saving history")
+		_ = s.Save()
+	}
+}
+
+// Size returns the number of entries currently held in memory.
+func (s *fileHistoryStore) Size() int {
+	return len(s.Entries)
+}
+
+// SetEnabled toggles whether Save persists entries to disk.
+func (s *fileHistoryStore) SetEnabled(enabled bool) {
+	s.Enabled = enabled
+}
+
+func (s *fileHistoryStore) appendEntry(e Entry) {
+	s.Entries = append(s.Entries, e)
+	s.compact()
+	s.Pos = len(s.Entries)
+}
+
+func (s *fileHistoryStore) compact() {
+	size := len(s.Entries)
+	if size > s.Limit {
+		logger.Debug("compacting history", slog.Int("size", size), slog.Int("limit", s.Limit))
+		s.Entries = s.Entries[size-s.Limit:]
+		logger.Debug("history compacted", slog.Int("size", len(s.Entries)))
+	}
+}
+
+func (s *fileHistoryStore) Clear() {
+	logger.Debug("clearing history")
+	s.Entries = nil
+}
+
+func (s *fileHistoryStore) Prev() (line string) {
+	logger.Debug("getting previous history entry", slog.Int("pos", s.Pos))
+	if s.Pos > 0 {
+		s.Pos -= 1
+	}
+	if s.Pos < len(s.Entries) {
+		line = s.Entries[s.Pos].Text
+	}
+	logger.Debug("returning history entry", slog.String("line", line), slog.Int("pos", s.Pos))
+	return line
+}
+
+func (s *fileHistoryStore) Next() (line string) {
+	logger.Debug("getting next history entry", slog.Int("pos", s.Pos))
+	if s.Pos < len(s.Entries) {
+		s.Pos += 1
+		if s.Pos < len(s.Entries) {
+			line = s.Entries[s.Pos].Text
+		}
+		logger.Debug("returning history entry", slog.String("line", line), slog.Int("pos", s.Pos))
+	} else {
+		logger.Debug("already at newest history position")
+	}
+	return line
+}
+
+func (s *fileHistoryStore) Search(query string, mode SearchMode) []SearchHit {
+	return searchLines(s.lines(), query, mode)
+}
+
+func (s *fileHistoryStore) lines() []string {
+	lines := make([]string, len(s.Entries))
+	for i, e := range s.Entries {
+		lines[i] = e.Text
+	}
+	return lines
+}
+
+func (s *fileHistoryStore) Save() error {
+	if !s.Enabled {
+		logger.Debug("history disabled, not saving")
+		return nil
+	}
+
+	logger.Debug("saving history to file", slog.String("path", s.Filename))
+	tmpFile := s.Filename + ".tmp"
+	logger.Debug("using temp file", slog.String("path", tmpFile))
+
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND, 0o600)
+	if err != nil {
+		logger.Error("failed to open temp file for writing", slog.String("path", tmpFile), "error", err)
+		return err
+	}
+	defer f.Close()
+
+	buf := bufio.NewWriter(f)
+	enc := json.NewEncoder(buf)
+	for _, e := range s.Entries {
+		if err := enc.Encode(e); err != nil {
+			logger.Error("failed to encode history entry", "error", err)
+			return err
+		}
+	}
+	buf.Flush()
+	f.Close()
+
+	logger.Debug("renaming temp file", slog.String("from", tmpFile), slog.String("to", s.Filename))
+	if err = os.Rename(tmpFile, s.Filename); err != nil {
+		logger.Error("failed to rename temp file", "error", err)
+		return err
+	}
+
+	logger.Debug("history saved successfully")
+	return nil
+}
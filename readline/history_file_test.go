@@ -0,0 +1,100 @@
+package readline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestFileStore(t *testing.T) *fileHistoryStore {
+	t.Helper()
+	s, err := newFileHistoryStore(filepath.Join(t.TempDir(), "history"))
+	if err != nil {
+		t.Fatalf("newFileHistoryStore() error = %v", err)
+	}
+	return s
+}
+
+func TestFileHistoryStoreIgnoreDups(t *testing.T) {
+	s := newTestFileStore(t)
+	s.SetPolicy(HistoryPolicy{IgnoreDups: true})
+
+	s.Add("ls")
+	s.Add("ls")
+	s.Add("pwd")
+
+	if got := s.lines(); len(got) != 2 || got[0] != "ls" || got[1] != "pwd" {
+		t.Fatalf("lines() = %v, want [ls pwd]", got)
+	}
+}
+
+func TestFileHistoryStoreIgnoreSpace(t *testing.T) {
+	s := newTestFileStore(t)
+	s.SetPolicy(HistoryPolicy{IgnoreSpace: true})
+
+	s.Add(" secret-command")
+	s.Add("ls")
+
+	if got := s.lines(); len(got) != 1 || got[0] != "ls" {
+		t.Fatalf("lines() = %v, want [ls]", got)
+	}
+}
+
+func TestFileHistoryStoreEraseDups(t *testing.T) {
+	s := newTestFileStore(t)
+	s.SetPolicy(HistoryPolicy{EraseDups: true})
+
+	s.Add("ls")
+	s.Add("pwd")
+	s.Add("ls")
+
+	if got := s.lines(); len(got) != 2 || got[0] != "pwd" || got[1] != "ls" {
+		t.Fatalf("lines() = %v, want [pwd ls]", got)
+	}
+}
+
+func TestFileHistoryStoreSaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	s, err := newFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newFileHistoryStore() error = %v", err)
+	}
+
+	s.AddEntry(Entry{Text: "ollama run llama3", SessionID: "abc", Model: "llama3"})
+
+	reloaded, err := newFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newFileHistoryStore() error = %v", err)
+	}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(reloaded.Entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(reloaded.Entries))
+	}
+	got := reloaded.Entries[0]
+	if got.Text != "ollama run llama3" || got.SessionID != "abc" || got.Model != "llama3" {
+		t.Fatalf("reloaded entry = %+v, want Text/SessionID/Model preserved", got)
+	}
+}
+
+func TestFileHistoryStoreLoadPlainText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	s, err := newFileHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newFileHistoryStore() error = %v", err)
+	}
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Simulate a pre-existing plain-text history file, one bare command per
+	// line, with no JSON metadata.
+	s.Entries = nil
+	s.appendEntry(parseHistoryLine("git status"))
+	s.appendEntry(parseHistoryLine("not json but looks close {ish"))
+
+	if len(s.Entries) != 2 || s.Entries[0].Text != "git status" || s.Entries[1].Text != "not json but looks close {ish" {
+		t.Fatalf("Entries = %+v, want both lines preserved as plain text", s.Entries)
+	}
+}
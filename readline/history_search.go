@@ -0,0 +1,104 @@
+package readline
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchLines applies mode to each candidate line against query. Substring
+// and case-insensitive modes preserve line order; fuzzy mode sorts hits by
+// descending score.
+func searchLines(lines []string, query string, mode SearchMode) []SearchHit {
+	if query == "" {
+		return nil
+	}
+
+	var hits []SearchHit
+	switch mode {
+	case SearchSubstring:
+		for _, line := range lines {
+			if strings.Contains(line, query) {
+				hits = append(hits, SearchHit{Text: line})
+			}
+		}
+	case SearchCaseInsensitive:
+		lower := strings.ToLower(query)
+		for _, line := range lines {
+			if strings.Contains(strings.ToLower(line), lower) {
+				hits = append(hits, SearchHit{Text: line})
+			}
+		}
+	case SearchFuzzy:
+		for _, line := range lines {
+			if score, positions, ok := fuzzyMatch(line, query); ok {
+				hits = append(hits, SearchHit{Text: line, Positions: positions, Score: score})
+			}
+		}
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	}
+
+	return hits
+}
+
+// fuzzyMatch is a small "fzf-lite" subsequence scorer: query's runes must
+// appear in line in order, but not necessarily contiguously. Consecutive
+// matches and matches at word starts (after a separator or a camelCase
+// boundary) score bonus points; gaps between matches cost a small penalty.
+func fuzzyMatch(line, query string) (score float64, positions []int, ok bool) {
+	if query == "" {
+		return 0, nil, false
+	}
+
+	runes := []rune(line)
+	want := []rune(strings.ToLower(query))
+
+	qi := 0
+	lastMatch := -1
+	for li, r := range runes {
+		if qi >= len(want) {
+			break
+		}
+		if unicode.ToLower(r) != want[qi] {
+			continue
+		}
+
+		points := 1.0
+		switch {
+		case li == 0:
+			points += 8
+		case isWordSeparator(runes[li-1]):
+			points += 8
+		case unicode.IsUpper(r) && unicode.IsLower(runes[li-1]):
+			points += 8
+		}
+
+		if lastMatch >= 0 {
+			if gap := li - lastMatch - 1; gap == 0 {
+				points += 5
+			} else {
+				points -= float64(gap) * 0.2
+			}
+		}
+
+		score += points
+		positions = append(positions, li)
+		lastMatch = li
+		qi++
+	}
+
+	if qi < len(want) {
+		return 0, nil, false
+	}
+
+	return score, positions, true
+}
+
+func isWordSeparator(r rune) bool {
+	switch r {
+	case ' ', '_', '-', '/', '.':
+		return true
+	default:
+		return false
+	}
+}
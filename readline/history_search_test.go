@@ -0,0 +1,58 @@
+package readline
+
+import "testing"
+
+func TestSearchLines(t *testing.T) {
+	lines := []string{"git status", "git commit -m foo", "go build ./...", "ls -la"}
+
+	hits := searchLines(lines, "git", SearchSubstring)
+	if len(hits) != 2 {
+		t.Fatalf("SearchSubstring: got %d hits, want 2", len(hits))
+	}
+
+	hits = searchLines(lines, "GIT", SearchCaseInsensitive)
+	if len(hits) != 2 {
+		t.Fatalf("SearchCaseInsensitive: got %d hits, want 2", len(hits))
+	}
+
+	if hits := searchLines(lines, "", SearchFuzzy); hits != nil {
+		t.Fatalf("empty query: got %v, want nil", hits)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	_, _, ok := fuzzyMatch("git commit", "gcm")
+	if !ok {
+		t.Fatal("fuzzyMatch(\"git commit\", \"gcm\") did not match")
+	}
+
+	if _, _, ok := fuzzyMatch("git commit", "xyz"); ok {
+		t.Fatal("fuzzyMatch(\"git commit\", \"xyz\") matched, want no match")
+	}
+
+	// A consecutive match at a word start should outscore the same
+	// consecutive match in the middle of a word.
+	wordStartScore, _, ok := fuzzyMatch("go build", "go")
+	if !ok {
+		t.Fatal("fuzzyMatch(\"go build\", \"go\") did not match")
+	}
+	midWordScore, _, ok := fuzzyMatch("ego work", "go")
+	if !ok {
+		t.Fatal("fuzzyMatch(\"ego work\", \"go\") did not match")
+	}
+	if wordStartScore <= midWordScore {
+		t.Fatalf("word-start match scored %v, want more than mid-word match %v", wordStartScore, midWordScore)
+	}
+}
+
+func TestSearchFuzzySortsByScore(t *testing.T) {
+	lines := []string{"ego work", "go build ./..."}
+
+	hits := searchLines(lines, "go", SearchFuzzy)
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(hits))
+	}
+	if hits[0].Text != "go build ./..." {
+		t.Fatalf("top hit = %q, want the word-start match first", hits[0].Text)
+	}
+}
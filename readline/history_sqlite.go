@@ -0,0 +1,253 @@
+package readline
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteHistoryStore persists entries to a SQLite database with columns
+// (id, timestamp, session_id, command, model). Each entry is tagged with
+// this store's session ID, but Load, Prev/Next and Search work over the
+// commands of every session; nothing filters by session. It implements EntryStore, so
+// History.AddEntry and History.SetPolicy reach it directly. The active
+// HistoryPolicy is persisted alongside the database in a sidecar
+// history.conf, the same as the file and encrypted drivers.
+type sqliteHistoryStore struct {
+	db        *sql.DB
+	path      string
+	sessionID string
+	pos       int
+	cache     []string
+	policy    HistoryPolicy
+	enabled   bool
+}
+
+// newSQLiteHistoryStore opens (creating if needed) a SQLite history database.
+// An empty path resolves to $HOME/.ollama/history.db.
+func newSQLiteHistoryStore(path string) (*sqliteHistoryStore, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			logger.Error("failed to get user home directory", "error", err)
+			return nil, err
+		}
+		path = filepath.Join(home, ".ollama", "history.db")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		logger.Error("failed to create history directory", "error", err)
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		logger.Error("failed to open sqlite history database", "error", err)
+		return nil, err
+	}
+
+	if _, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			timestamp INTEGER NOT NULL,
+			session_id TEXT NOT NULL,
+			command TEXT NOT NULL,
+			model TEXT
+		)
+	`); err != nil {
+		db.Close()
+		logger.Error("failed to create history table", "error", err)
+		return nil, err
+	}
+
+	return &sqliteHistoryStore{db: db, path: path, sessionID: newSessionID(), enabled: true}, nil
+}
+
+// policyPath is the sidecar file the active HistoryPolicy is persisted to,
+// alongside the database itself -- matching fileHistoryStore's policyPath.
+func (s *sqliteHistoryStore) policyPath() string {
+	return filepath.Join(filepath.Dir(s.path), "history.conf")
+}
+
+func (s *sqliteHistoryStore) savePolicy() error {
+	f, err := os.OpenFile(s.policyPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(s.policy)
+}
+
+func (s *sqliteHistoryStore) loadPolicy() error {
+	f, err := os.Open(s.policyPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&s.policy); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func (s *sqliteHistoryStore) Load() error {
+	logger.Debug("loading history from sqlite")
+	if err := s.loadPolicy(); err != nil {
+		logger.Error("failed to load history policy", "error", err)
+		return err
+	}
+
+	rows, err := s.db.Query(`SELECT command FROM history ORDER BY id ASC`)
+	if err != nil {
+		logger.Error("failed to query sqlite history", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	s.cache = s.cache[:0]
+	for rows.Next() {
+		var command string
+		if err := rows.Scan(&command); err != nil {
+			return err
+		}
+		s.cache = append(s.cache, command)
+	}
+
+	s.pos = len(s.cache)
+	return rows.Err()
+}
+
+// Add appends a plain-text entry, stamping it with the current time.
+func (s *sqliteHistoryStore) Add(line string) {
+	s.AddEntry(Entry{Text: line})
+}
+
+// AddEntry inserts e -- including its Model -- applying the active
+// HistoryPolicy (IgnoreDups, IgnoreSpace, EraseDups) the same way the
+// file-backed driver does. When the store is disabled via SetEnabled, the
+// entry still lands in the in-memory cache for this session's recall, but
+// isn't persisted to the database.
+func (s *sqliteHistoryStore) AddEntry(e Entry) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	if s.policy.IgnoreSpace && strings.TrimLeftFunc(e.Text, unicode.IsSpace) != e.Text {
+		logger.Debug("ignoring entry with leading whitespace per policy")
+		return
+	}
+
+	if s.policy.IgnoreDups && len(s.cache) > 0 && s.cache[len(s.cache)-1] == e.Text {
+		logger.Debug("ignoring duplicate entry per policy")
+		return
+	}
+
+	if s.policy.EraseDups {
+		if s.enabled {
+			if _, err := s.db.Exec(`DELETE FROM history WHERE command = ?`, e.Text); err != nil {
+				logger.Error("failed to erase duplicate sqlite history entries", "error", err)
+			}
+		}
+		kept := s.cache[:0]
+		for _, existing := range s.cache {
+			if existing != e.Text {
+				kept = append(kept, existing)
+			}
+		}
+		s.cache = kept
+	}
+
+	if s.enabled {
+		logger.Debug("adding new entry to sqlite history", slog.String("entry", e.Text))
+		if _, err := s.db.Exec(
+			`INSERT INTO history (timestamp, session_id, command, model) VALUES (?, ?, ?, ?)`,
+			e.Timestamp.Unix(), s.sessionID, e.Text, e.Model,
+		); err != nil {
+			logger.Error("failed to insert history entry", "error", err)
+			return
+		}
+	}
+
+	s.cache = append(s.cache, e.Text)
+	s.pos = len(s.cache)
+}
+
+// SetPolicy replaces the active HistoryPolicy and persists it to the sidecar
+// config file so it survives across sessions, the same as the file-backed
+// driver.
+func (s *sqliteHistoryStore) SetPolicy(p HistoryPolicy) {
+	s.policy = p
+	if err := s.savePolicy(); err != nil {
+		logger.Error("failed to save history policy", "error", err)
+	}
+}
+
+func (s *sqliteHistoryStore) Prev() (line string) {
+	if s.pos > 0 {
+		s.pos--
+	}
+	if s.pos < len(s.cache) {
+		line = s.cache[s.pos]
+	}
+	return line
+}
+
+func (s *sqliteHistoryStore) Next() (line string) {
+	if s.pos < len(s.cache) {
+		s.pos++
+	}
+	if s.pos < len(s.cache) {
+		line = s.cache[s.pos]
+	}
+	return line
+}
+
+func (s *sqliteHistoryStore) Search(query string, mode SearchMode) []SearchHit {
+	return searchLines(s.cache, query, mode)
+}
+
+// Clear wipes every session's history, matching the file-backed driver's
+// Clear -- not just the rows belonging to the current session.
+func (s *sqliteHistoryStore) Clear() {
+	logger.Debug("clearing sqlite history")
+	if _, err := s.db.Exec(`DELETE FROM history`); err != nil {
+		logger.Error("failed to clear sqlite history", "error", err)
+	}
+	s.cache = nil
+	s.pos = 0
+}
+
+func (s *sqliteHistoryStore) Save() error {
+	// entries are persisted as they're added, so there's nothing to flush.
+	return nil
+}
+
+// Size returns the number of entries currently cached in memory, from every
+// session.
+func (s *sqliteHistoryStore) Size() int {
+	return len(s.cache)
+}
+
+// SetEnabled toggles whether AddEntry persists new entries to the database.
+func (s *sqliteHistoryStore) SetEnabled(enabled bool) {
+	s.enabled = enabled
+}
+
+func newSessionID() string {
+	return fmt.Sprintf("%d-%d", os.Getpid(), time.Now().UnixNano())
+}
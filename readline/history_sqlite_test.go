@@ -0,0 +1,108 @@
+package readline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestSQLiteStore(t *testing.T) *sqliteHistoryStore {
+	t.Helper()
+	s, err := newSQLiteHistoryStore(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("newSQLiteHistoryStore() error = %v", err)
+	}
+	return s
+}
+
+func modelColumn(t *testing.T, s *sqliteHistoryStore, command string) string {
+	t.Helper()
+	var model string
+	err := s.db.QueryRow(`SELECT model FROM history WHERE command = ?`, command).Scan(&model)
+	if err != nil {
+		t.Fatalf("query model column for %q: %v", command, err)
+	}
+	return model
+}
+
+func TestSQLiteHistoryStoreModelColumnPopulated(t *testing.T) {
+	s := newTestSQLiteStore(t)
+
+	s.AddEntry(Entry{Text: "ollama run llama3", Model: "llama3"})
+
+	if got := modelColumn(t, s, "ollama run llama3"); got != "llama3" {
+		t.Fatalf("model column = %q, want %q", got, "llama3")
+	}
+}
+
+func TestSQLiteHistoryStoreClearWipesAllSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	a, err := newSQLiteHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newSQLiteHistoryStore() error = %v", err)
+	}
+	a.AddEntry(Entry{Text: "command from session a"})
+
+	b, err := newSQLiteHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newSQLiteHistoryStore() error = %v", err)
+	}
+	b.AddEntry(Entry{Text: "command from session b"})
+
+	if a.sessionID == b.sessionID {
+		t.Fatal("expected distinct session IDs for two stores opened against the same database")
+	}
+
+	b.Clear()
+
+	if err := a.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(a.cache) != 0 {
+		t.Fatalf("after Clear() from another session, cache = %v, want empty", a.cache)
+	}
+}
+
+func TestSQLiteHistoryStorePolicyPersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	s, err := newSQLiteHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newSQLiteHistoryStore() error = %v", err)
+	}
+	s.SetPolicy(HistoryPolicy{IgnoreDups: true, EraseDups: true})
+
+	reloaded, err := newSQLiteHistoryStore(path)
+	if err != nil {
+		t.Fatalf("newSQLiteHistoryStore() error = %v", err)
+	}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := HistoryPolicy{IgnoreDups: true, EraseDups: true}
+	if reloaded.policy != want {
+		t.Fatalf("policy after restart = %+v, want %+v", reloaded.policy, want)
+	}
+}
+
+func TestSQLiteHistoryStoreEraseDupsDisabledDoesNotTouchDatabase(t *testing.T) {
+	s := newTestSQLiteStore(t)
+	s.SetPolicy(HistoryPolicy{EraseDups: true})
+
+	s.AddEntry(Entry{Text: "ollama run llama3"})
+	if got := modelColumn(t, s, "ollama run llama3"); got != "" {
+		t.Fatalf("model column = %q, want empty", got)
+	}
+
+	s.SetEnabled(false)
+	s.AddEntry(Entry{Text: "ollama run llama3"})
+
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM history WHERE command = ?`, "ollama run llama3").Scan(&count); err != nil {
+		t.Fatalf("query count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("row count for %q = %d, want 1 (EraseDups on a disabled store must not delete persisted rows)", "ollama run llama3", count)
+	}
+}
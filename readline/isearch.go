@@ -0,0 +1,78 @@
+package readline
+
+// Reverse incremental search key bindings, in the same ASCII control-code
+// style as the rest of the readline key handling.
+const (
+	KeyCtrlR = 18 // opens/cycles the reverse search prompt
+	KeyCtrlG = 7  // cancels the reverse search prompt
+)
+
+// ISearch drives a Ctrl-R style incremental reverse search over a History.
+// The terminal read loop feeds it keystrokes and renders whatever Line
+// returns; Accept and Cancel end the session.
+type ISearch struct {
+	history *History
+	saved   string
+	query   []rune
+	hits    []SearchHit
+	index   int
+}
+
+// NewISearch starts a reverse search session. buf is the line being edited
+// when the search began, and is restored on Cancel.
+func NewISearch(h *History, buf string) *ISearch {
+	return &ISearch{history: h, saved: buf}
+}
+
+// Rune appends r to the search query and re-runs the fuzzy search.
+func (s *ISearch) Rune(r rune) {
+	s.query = append(s.query, r)
+	s.refresh()
+}
+
+// Backspace removes the last query rune and re-runs the fuzzy search.
+func (s *ISearch) Backspace() {
+	if len(s.query) == 0 {
+		return
+	}
+	s.query = s.query[:len(s.query)-1]
+	s.refresh()
+}
+
+// Next cycles to the next match for the current query; repeated Ctrl-R
+// presses call this to step through hits, wrapping around at the end.
+func (s *ISearch) Next() {
+	if len(s.hits) == 0 {
+		return
+	}
+	s.index = (s.index + 1) % len(s.hits)
+}
+
+func (s *ISearch) refresh() {
+	s.hits = s.history.Search(string(s.query), SearchFuzzy)
+	s.index = 0
+}
+
+// Query returns the search string typed so far.
+func (s *ISearch) Query() string {
+	return string(s.query)
+}
+
+// Line returns the buffer to display: the current hit while a query is
+// active, or the original buffer if there's no query or no match.
+func (s *ISearch) Line() string {
+	if len(s.query) == 0 || len(s.hits) == 0 {
+		return s.saved
+	}
+	return s.hits[s.index].Text
+}
+
+// Accept ends the search, keeping the currently displayed line as the buffer.
+func (s *ISearch) Accept() string {
+	return s.Line()
+}
+
+// Cancel ends the search, restoring the buffer captured at NewISearch.
+func (s *ISearch) Cancel() string {
+	return s.saved
+}
@@ -0,0 +1,71 @@
+package readline
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestISearchHistory(t *testing.T) *History {
+	t.Helper()
+	store, err := newFileHistoryStore(filepath.Join(t.TempDir(), "history"))
+	if err != nil {
+		t.Fatalf("newFileHistoryStore() error = %v", err)
+	}
+	h, err := NewHistoryWithStore(store)
+	if err != nil {
+		t.Fatalf("NewHistoryWithStore() error = %v", err)
+	}
+	h.Add("go build ./...")
+	h.Add("go test ./...")
+	h.Add("go vet ./...")
+	return h
+}
+
+func TestISearchNextWrapsAtEndOfHits(t *testing.T) {
+	s := NewISearch(newTestISearchHistory(t), "")
+	for _, r := range "go" {
+		s.Rune(r)
+	}
+	if len(s.hits) != 3 {
+		t.Fatalf("got %d hits, want 3", len(s.hits))
+	}
+
+	s.Next()
+	s.Next()
+	if s.index != 2 {
+		t.Fatalf("index after two Next() = %d, want 2", s.index)
+	}
+
+	s.Next()
+	if s.index != 0 {
+		t.Fatalf("index after wrapping Next() = %d, want 0", s.index)
+	}
+}
+
+func TestISearchCancelRestoresSavedBuffer(t *testing.T) {
+	s := NewISearch(newTestISearchHistory(t), "original buffer")
+	for _, r := range "go test" {
+		s.Rune(r)
+	}
+
+	if got := s.Cancel(); got != "original buffer" {
+		t.Fatalf("Cancel() = %q, want %q", got, "original buffer")
+	}
+}
+
+func TestISearchLineFallsBackToSavedOnceQueryEmptied(t *testing.T) {
+	s := NewISearch(newTestISearchHistory(t), "original buffer")
+	for _, r := range "go" {
+		s.Rune(r)
+	}
+	if got := s.Line(); got == "original buffer" {
+		t.Fatal("Line() returned saved buffer while a matching query is active")
+	}
+
+	s.Backspace()
+	s.Backspace()
+
+	if got := s.Line(); got != "original buffer" {
+		t.Fatalf("Line() after emptying query = %q, want saved buffer %q", got, "original buffer")
+	}
+}
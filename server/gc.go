@@ -0,0 +1,218 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ollama/ollama/types/model"
+)
+
+// GCOptions configures a GC run.
+type GCOptions struct {
+	// DryRun reports what would be removed without deleting anything.
+	DryRun bool
+	// MinAge only prunes blobs whose mtime is older than this. Zero prunes
+	// regardless of age.
+	MinAge time.Duration
+	// KeepStorage stops pruning, oldest-unreferenced blob first, once total
+	// blob usage falls to or below this many bytes. Zero means no floor:
+	// every unreferenced, old-enough blob is removed.
+	KeepStorage int64
+	// Progress, if set, is called after each blob is (or, under DryRun,
+	// would be) removed.
+	Progress func(digest string, bytesReclaimed int64)
+}
+
+// GCReport summarizes a GC run.
+type GCReport struct {
+	BytesReclaimed int64
+	BlobsRemoved   int
+	// ReferencedBlobs is the count of distinct layer digests (including
+	// Config) each manifest references.
+	ReferencedBlobs map[model.Name]int
+}
+
+// GC walks every manifest via Manifests(true) -- which parses JSON metadata
+// only and does not call Verify -- to build the set of referenced layer
+// digests, then deletes any blob in the blobs directory that isn't
+// referenced. Using the unverified listing is deliberate: a manifest with
+// one corrupted layer must still contribute its other, still-valid layers to
+// the referenced set, or GC would treat them as orphans and delete blobs
+// that are very much still in use. Candidates are pruned oldest-first;
+// MinAge skips blobs that haven't aged out yet, and KeepStorage stops the
+// pass early once usage falls to the requested floor.
+func GC(opts GCOptions) (GCReport, error) {
+	logger.Debug("starting garbage collection", slog.Bool("dry_run", opts.DryRun))
+	report := GCReport{ReferencedBlobs: make(map[model.Name]int)}
+
+	manifests, err := Manifests(true)
+	if err != nil {
+		logger.Error("failed to list manifests for gc", "error", err)
+		return report, err
+	}
+
+	referenced := make(map[string]bool)
+	for name, m := range manifests {
+		refs := make(map[string]bool)
+		for _, layer := range append(m.Layers, m.Config) {
+			if layer.Digest == "" {
+				continue
+			}
+			refs[layer.Digest] = true
+			referenced[layer.Digest] = true
+		}
+		report.ReferencedBlobs[name] = len(refs)
+	}
+
+	blobs, err := GetBlobsPath("")
+	if err != nil {
+		logger.Error("failed to get blobs path", "error", err)
+		return report, err
+	}
+
+	entries, err := os.ReadDir(blobs)
+	if err != nil {
+		logger.Error("failed to read blobs directory", slog.String("path", blobs), "error", err)
+		return report, err
+	}
+
+	type candidate struct {
+		path    string
+		digest  string
+		size    int64
+		modTime time.Time
+	}
+
+	now := time.Now()
+	var keptBytes int64
+	var orphans []candidate
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			logger.Warn("failed to stat blob", slog.String("name", entry.Name()), "error", err)
+			continue
+		}
+
+		digest := digestFromBlobFilename(entry.Name())
+		if referenced[digest] {
+			keptBytes += info.Size()
+			continue
+		}
+
+		if opts.MinAge > 0 && now.Sub(info.ModTime()) < opts.MinAge {
+			logger.Debug("skipping orphan blob younger than MinAge", slog.String("digest", digest))
+			keptBytes += info.Size()
+			continue
+		}
+
+		orphans = append(orphans, candidate{
+			path:    filepath.Join(blobs, entry.Name()),
+			digest:  digest,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].modTime.Before(orphans[j].modTime) })
+
+	usage := keptBytes
+	for _, o := range orphans {
+		usage += o.size
+	}
+
+	for _, o := range orphans {
+		if opts.KeepStorage > 0 && usage <= opts.KeepStorage {
+			logger.Debug("usage at or below KeepStorage, stopping prune", slog.Int64("usage", usage), slog.Int64("keep_storage", opts.KeepStorage))
+			break
+		}
+
+		logger.Debug("pruning orphan blob", slog.String("digest", o.digest), slog.Int64("size", o.size))
+		if !opts.DryRun {
+			if err := os.Remove(o.path); err != nil {
+				logger.Error("failed to remove orphan blob", slog.String("path", o.path), "error", err)
+				return report, err
+			}
+		}
+
+		usage -= o.size
+		report.BytesReclaimed += o.size
+		report.BlobsRemoved++
+		if opts.Progress != nil {
+			opts.Progress(o.digest, o.size)
+		}
+	}
+
+	logger.Debug("garbage collection complete", slog.Int("blobs_removed", report.BlobsRemoved), slog.Int64("bytes_reclaimed", report.BytesReclaimed))
+	return report, nil
+}
+
+// digestFromBlobFilename recovers a "algo:hex" digest from the on-disk blob
+// filename, which stores the colon as a dash (sha256-abc -> sha256:abc).
+func digestFromBlobFilename(name string) string {
+	if algo, hex, ok := strings.Cut(name, "-"); ok {
+		return algo + ":" + hex
+	}
+	return name
+}
+
+// PruneManifests removes every manifest whose layers (including Config)
+// can't all be resolved to a blob on disk -- the result of a corrupt or
+// partial install -- and returns the names removed so the caller can log
+// them. It sources its listing from Manifests(true), not VerifyManifests:
+// Verify would have already dropped exactly the corrupt manifests this
+// function exists to find, so it needs the raw, unverified parse and does
+// its own os.Stat-based resolution check below.
+func PruneManifests() ([]model.Name, error) {
+	logger.Debug("pruning manifests with unresolved layers")
+	manifests, err := Manifests(true)
+	if err != nil {
+		logger.Error("failed to list manifests for prune", "error", err)
+		return nil, err
+	}
+
+	var removed []model.Name
+	for name, m := range manifests {
+		corrupt := false
+		for _, layer := range append(m.Layers, m.Config) {
+			if layer.Digest == "" {
+				continue
+			}
+
+			blob, err := GetBlobsPath(layer.Digest)
+			if err != nil {
+				return removed, err
+			}
+
+			if _, err := os.Stat(blob); errors.Is(err, os.ErrNotExist) {
+				logger.Warn("manifest references unresolved layer", slog.String("name", name.String()), slog.String("digest", layer.Digest))
+				corrupt = true
+				break
+			} else if err != nil {
+				logger.Error("failed to stat layer blob", slog.String("digest", layer.Digest), "error", err)
+				return removed, err
+			}
+		}
+
+		if !corrupt {
+			continue
+		}
+
+		if err := m.Remove(); err != nil {
+			logger.Error("failed to remove corrupt manifest", slog.String("name", name.String()), "error", err)
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+
+	logger.Debug("manifest prune complete", slog.Int("removed", len(removed)))
+	return removed, nil
+}
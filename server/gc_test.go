@@ -0,0 +1,140 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ollama/ollama/types/model"
+)
+
+func TestGCDryRunLeavesBlobsAlone(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	orphan := writeTestBlob(t, []byte("orphan"))
+	blob, err := GetBlobsPath(orphan)
+	if err != nil {
+		t.Fatalf("GetBlobsPath() error = %v", err)
+	}
+
+	report, err := GC(GCOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+	if report.BlobsRemoved != 1 || report.BytesReclaimed == 0 {
+		t.Fatalf("report = %+v, want one orphan blob reported as removed", report)
+	}
+	if _, err := os.Stat(blob); err != nil {
+		t.Fatalf("blob removed during DryRun: %v", err)
+	}
+}
+
+func TestGCMinAgeExcludesYoungOrphans(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	orphan := writeTestBlob(t, []byte("fresh orphan"))
+	blob, err := GetBlobsPath(orphan)
+	if err != nil {
+		t.Fatalf("GetBlobsPath() error = %v", err)
+	}
+
+	report, err := GC(GCOptions{MinAge: time.Hour})
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+	if report.BlobsRemoved != 0 {
+		t.Fatalf("report = %+v, want MinAge to exclude a blob younger than an hour", report)
+	}
+	if _, err := os.Stat(blob); err != nil {
+		t.Fatalf("blob removed despite MinAge: %v", err)
+	}
+}
+
+func TestGCKeepStorageStopsAtFloor(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	older := writeTestBlob(t, []byte("older orphan"))
+	olderPath, err := GetBlobsPath(older)
+	if err != nil {
+		t.Fatalf("GetBlobsPath() error = %v", err)
+	}
+	olderTime := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(olderPath, olderTime, olderTime); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	newer := writeTestBlob(t, []byte("newer"))
+	newerPath, err := GetBlobsPath(newer)
+	if err != nil {
+		t.Fatalf("GetBlobsPath() error = %v", err)
+	}
+	newerTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(newerPath, newerTime, newerTime); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	newerInfo, err := os.Stat(newerPath)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+
+	report, err := GC(GCOptions{KeepStorage: newerInfo.Size()})
+	if err != nil {
+		t.Fatalf("GC() error = %v", err)
+	}
+	if report.BlobsRemoved != 1 {
+		t.Fatalf("report = %+v, want exactly the older orphan removed", report)
+	}
+	if _, err := os.Stat(olderPath); !os.IsNotExist(err) {
+		t.Fatalf("older orphan still present, want it removed first")
+	}
+	if _, err := os.Stat(newerPath); err != nil {
+		t.Fatalf("newer orphan removed, want it kept once usage falls to KeepStorage: %v", err)
+	}
+}
+
+func TestPruneManifestsRemovesUnresolvedLayer(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	config := Layer{Digest: writeTestBlob(t, []byte("config")), Size: 6}
+	missing := Layer{Digest: "sha256:" + strings.Repeat("0", 64), Size: 1}
+	name := model.ParseName("library/corrupt:latest")
+	if err := WriteManifest(name, config, []Layer{missing}); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	removed, err := PruneManifests()
+	if err != nil {
+		t.Fatalf("PruneManifests() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0] != name {
+		t.Fatalf("removed = %v, want exactly [%q]", removed, name)
+	}
+
+	manifests, err := GetManifestPath()
+	if err != nil {
+		t.Fatalf("GetManifestPath() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(manifests, name.Filepath())); !os.IsNotExist(err) {
+		t.Fatal("corrupt manifest file still present after PruneManifests")
+	}
+}
+
+func TestDigestFromBlobFilename(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"sha256-abc123", "sha256:abc123"},
+		{"sha256-abc-def", "sha256:abc-def"},
+		{"nodashatall", "nodashatall"},
+	}
+
+	for _, c := range cases {
+		if got := digestFromBlobFilename(c.name); got != c.want {
+			t.Errorf("digestFromBlobFilename(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
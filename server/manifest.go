@@ -1,6 +1,7 @@
 package server
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -11,9 +12,35 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ollama/ollama/internal/crypto"
+	"github.com/ollama/ollama/internal/logging"
 	"github.com/ollama/ollama/types/model"
 )
 
+var logger = logging.Logger()
+
+// manifestMagic prefixes an encrypted manifest body on disk so ParseNamedManifest
+// can tell it apart from a plain JSON manifest without a separate file extension.
+var manifestMagic = []byte("OLLAMAENCv1")
+
+// ErrDigestMismatch is returned by Manifest.Verify when a layer's blob on disk
+// no longer hashes to the digest recorded in the manifest.
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// DigestMismatchError reports which layer failed verification in Manifest.Verify.
+type DigestMismatchError struct {
+	Digest string
+	Actual string
+}
+
+func (e *DigestMismatchError) Error() string {
+	return fmt.Sprintf("digest mismatch for %s: blob hashes to %s", e.Digest, e.Actual)
+}
+
+func (e *DigestMismatchError) Unwrap() error {
+	return ErrDigestMismatch
+}
+
 type Manifest struct {
 	SchemaVersion int     `json:"schemaVersion"`
 	MediaType     string  `json:"mediaType"`
@@ -26,110 +53,177 @@ type Manifest struct {
 }
 
 func (m *Manifest) Size() (size int64) {
-	fmt.Printf("Calculating manifest size for %s\n", m.filepath)
+	logger.Debug("calculating manifest size", slog.String("path", m.filepath))
 	for _, layer := range append(m.Layers, m.Config) {
 		size += layer.Size
 	}
-	fmt.Printf("Total manifest size: %d bytes\n", size)
+	logger.Debug("calculated manifest size", slog.Int64("size", size))
 	return
 }
 
 func (m *Manifest) Remove() error {
-	fmt.Printf("Removing manifest file: %s\n", m.filepath)
+	logger.Debug("removing manifest file", slog.String("path", m.filepath))
 	if err := os.Remove(m.filepath); err != nil {
-		fmt.Printf("Error removing manifest file: %v\n", err)
+		logger.Error("failed to remove manifest file", "error", err)
 		return err
 	}
 
 	manifests, err := GetManifestPath()
 	if err != nil {
-		fmt.Printf("Error getting manifest path: %v\n", err)
+		logger.Error("failed to get manifest path", "error", err)
 		return err
 	}
 
-	fmt.Println("Pruning empty directories in manifest path")
+	logger.Debug("pruning empty directories in manifest path")
 	return PruneDirectory(manifests)
 }
 
 func (m *Manifest) RemoveLayers() error {
-	fmt.Printf("Removing layers for manifest %s\n", m.filepath)
+	logger.Debug("removing layers for manifest", slog.String("path", m.filepath))
 	for _, layer := range append(m.Layers, m.Config) {
 		if layer.Digest != "" {
-			fmt.Printf("Removing layer with digest: %s\n", layer.Digest)
+			logger.Debug("removing layer", slog.String("digest", layer.Digest))
 			if err := layer.Remove(); errors.Is(err, os.ErrNotExist) {
-				slog.Debug("layer does not exist", "digest", layer.Digest)
+				logger.Debug("layer does not exist", "digest", layer.Digest)
 			} else if err != nil {
-				fmt.Printf("Error removing layer %s: %v\n", layer.Digest, err)
+				logger.Error("failed to remove layer", slog.String("digest", layer.Digest), "error", err)
 				return err
 			}
 		}
 	}
-	fmt.Println("All layers removed successfully")
+	logger.Debug("all layers removed successfully")
+	return nil
+}
+
+// Verify re-hashes each layer's blob file on disk and cross-checks it against
+// the digest recorded in the manifest, returning a *DigestMismatchError for
+// the first layer whose blob no longer matches.
+func (m *Manifest) Verify() error {
+	logger.Debug("verifying layer digests", slog.String("path", m.filepath))
+	for _, layer := range append(m.Layers, m.Config) {
+		if layer.Digest == "" {
+			continue
+		}
+
+		if err := verifyLayerDigest(layer); err != nil {
+			logger.Warn("digest verification failed", slog.String("digest", layer.Digest), "error", err)
+			return err
+		}
+	}
+
+	logger.Debug("all layer digests verified successfully")
+	return nil
+}
+
+func verifyLayerDigest(layer Layer) error {
+	blob, err := GetBlobsPath(layer.Digest)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(blob)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sha256sum := sha256.New()
+	if _, err := io.Copy(sha256sum, f); err != nil {
+		return err
+	}
+
+	actual := "sha256:" + hex.EncodeToString(sha256sum.Sum(nil))
+	if actual != layer.Digest {
+		return &DigestMismatchError{Digest: layer.Digest, Actual: actual}
+	}
+
 	return nil
 }
 
 func ParseNamedManifest(n model.Name) (*Manifest, error) {
-	fmt.Printf("Parsing named manifest: %s\n", n)
+	logger.Debug("parsing named manifest", slog.String("name", n.String()))
 	if !n.IsFullyQualified() {
-		fmt.Printf("Name is not fully qualified: %s\n", n)
+		logger.Debug("name is not fully qualified", slog.String("name", n.String()))
 		return nil, model.Unqualified(n)
 	}
 
 	manifests, err := GetManifestPath()
 	if err != nil {
-		fmt.Printf("Error getting manifest path: %v\n", err)
+		logger.Error("failed to get manifest path", "error", err)
 		return nil, err
 	}
 
 	p := filepath.Join(manifests, n.Filepath())
-	fmt.Printf("Manifest file path: %s\n", p)
+	logger.Debug("resolved manifest file path", slog.String("path", p))
 
 	var m Manifest
 	f, err := os.Open(p)
 	if err != nil {
-		fmt.Printf("Error opening manifest file: %v\n", err)
+		logger.Error("failed to open manifest file", slog.String("path", p), "error", err)
 		return nil, err
 	}
 	defer f.Close()
 
 	fi, err := f.Stat()
 	if err != nil {
-		fmt.Printf("Error getting file info: %v\n", err)
+		logger.Error("failed to stat manifest file", "error", err)
 		return nil, err
 	}
 
 	sha256sum := sha256.New()
-	if err := json.NewDecoder(io.TeeReader(f, sha256sum)).Decode(&m); err != nil {
-		fmt.Printf("Error decoding manifest JSON: %v\n", err)
+	raw, err := io.ReadAll(io.TeeReader(f, sha256sum))
+	if err != nil {
+		logger.Error("failed to read manifest file", "error", err)
+		return nil, err
+	}
+
+	body := raw
+	if bytes.HasPrefix(raw, manifestMagic) {
+		logger.Debug("manifest is encrypted, decrypting", slog.String("path", p))
+		key, err := manifestKey()
+		if err != nil {
+			logger.Error("failed to resolve manifest key", "error", err)
+			return nil, err
+		}
+
+		body, err = decryptManifestBody(raw[len(manifestMagic):], key)
+		if err != nil {
+			logger.Error("failed to decrypt manifest", "error", err)
+			return nil, err
+		}
+	}
+
+	if err := json.Unmarshal(body, &m); err != nil {
+		logger.Error("failed to decode manifest JSON", "error", err)
 		return nil, err
 	}
 
 	m.filepath = p
 	m.fi = fi
 	m.digest = hex.EncodeToString(sha256sum.Sum(nil))
-	fmt.Printf("Manifest parsed successfully, digest: %s\n", m.digest)
+	logger.Debug("manifest parsed successfully", slog.String("digest", m.digest))
 
 	return &m, nil
 }
 
 func WriteManifest(name model.Name, config Layer, layers []Layer) error {
-	fmt.Printf("Writing manifest for: %s\n", name)
+	logger.Debug("writing manifest", slog.String("name", name.String()))
 	manifests, err := GetManifestPath()
 	if err != nil {
-		fmt.Printf("Error getting manifest path: %v\n", err)
+		logger.Error("failed to get manifest path", "error", err)
 		return err
 	}
 
 	p := filepath.Join(manifests, name.Filepath())
-	fmt.Printf("Writing manifest to: %s\n", p)
+	logger.Debug("writing manifest to path", slog.String("path", p))
 	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
-		fmt.Printf("Error creating directories: %v\n", err)
+		logger.Error("failed to create directories", "error", err)
 		return err
 	}
 
 	f, err := os.Create(p)
 	if err != nil {
-		fmt.Printf("Error creating manifest file: %v\n", err)
+		logger.Error("failed to create manifest file", "error", err)
 		return err
 	}
 	defer f.Close()
@@ -141,39 +235,139 @@ func WriteManifest(name model.Name, config Layer, layers []Layer) error {
 		Layers:        layers,
 	}
 
-	fmt.Println("Encoding manifest to JSON")
+	logger.Debug("encoding manifest to JSON")
 	if err := json.NewEncoder(f).Encode(m); err != nil {
-		fmt.Printf("Error encoding manifest: %v\n", err)
+		logger.Error("failed to encode manifest", "error", err)
 		return err
 	}
 
-	fmt.Println("Manifest written successfully")
+	logger.Debug("manifest written successfully")
 	return nil
 }
 
-func Manifests(continueOnError bool) (map[model.Name]*Manifest, error) {
-	fmt.Println("Listing all manifests")
+// WriteEncryptedManifest writes name's manifest with its JSON body AES-GCM
+// encrypted under key, storing the nonce alongside the ciphertext. The file
+// is written to a temporary path and atomically renamed into place, mirroring
+// WriteManifest's layout so ParseNamedManifest can read either format back.
+func WriteEncryptedManifest(name model.Name, config Layer, layers []Layer, key []byte) error {
+	logger.Debug("writing encrypted manifest", slog.String("name", name.String()))
 	manifests, err := GetManifestPath()
 	if err != nil {
-		fmt.Printf("Error getting manifest path: %v\n", err)
+		logger.Error("failed to get manifest path", "error", err)
+		return err
+	}
+
+	p := filepath.Join(manifests, name.Filepath())
+	logger.Debug("writing encrypted manifest to path", slog.String("path", p))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		logger.Error("failed to create directories", "error", err)
+		return err
+	}
+
+	m := Manifest{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.docker.distribution.manifest.v2+json",
+		Config:        config,
+		Layers:        layers,
+	}
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		logger.Error("failed to encode manifest", "error", err)
+		return err
+	}
+
+	sealed, err := encryptManifestBody(body, key)
+	if err != nil {
+		logger.Error("failed to encrypt manifest", "error", err)
+		return err
+	}
+
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(manifestMagic, sealed...), 0o600); err != nil {
+		logger.Error("failed to write temp manifest file", "error", err)
+		return err
+	}
+
+	logger.Debug("renaming temp file", slog.String("from", tmp), slog.String("to", p))
+	if err := os.Rename(tmp, p); err != nil {
+		logger.Error("failed to rename temp manifest file", "error", err)
+		return err
+	}
+
+	logger.Debug("encrypted manifest written successfully")
+	return nil
+}
+
+// encryptManifestBody seals body with the shared AES-GCM envelope under key.
+func encryptManifestBody(body, key []byte) ([]byte, error) {
+	return crypto.Seal(body, key)
+}
+
+// decryptManifestBody reverses encryptManifestBody.
+func decryptManifestBody(sealed, key []byte) ([]byte, error) {
+	return crypto.Open(sealed, key)
+}
+
+// manifestKey resolves the AES-256 key used for encrypted manifests from
+// OLLAMA_MANIFEST_KEY, which must be 64 hex characters (32 bytes).
+func manifestKey() ([]byte, error) {
+	s := os.Getenv("OLLAMA_MANIFEST_KEY")
+	if s == "" {
+		return nil, errors.New("OLLAMA_MANIFEST_KEY is not set")
+	}
+
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OLLAMA_MANIFEST_KEY: %w", err)
+	}
+
+	if len(key) != 32 {
+		return nil, errors.New("OLLAMA_MANIFEST_KEY must decode to 32 bytes")
+	}
+
+	return key, nil
+}
+
+// Manifests walks every manifest under the manifest path and parses each
+// one's JSON metadata. It deliberately does not call Verify -- re-hashing
+// every layer blob of every installed model is an O(total blob size) pass,
+// not a metadata read, and callers like GC that just need the set of
+// referenced digests shouldn't pay for it or have a manifest with one bad
+// layer silently vanish from the result. Use VerifyManifests when blob
+// integrity actually needs checking. When continueOnError is set, entries
+// that fail to parse are skipped rather than aborting the walk; onInvalid, if
+// provided, is called with each skipped entry's name and error so callers
+// like `ollama list` can flag corrupt entries instead of silently dropping
+// them.
+func Manifests(continueOnError bool, onInvalid ...func(name model.Name, err error)) (map[model.Name]*Manifest, error) {
+	var invalid func(name model.Name, err error)
+	if len(onInvalid) > 0 {
+		invalid = onInvalid[0]
+	}
+
+	logger.Debug("listing all manifests")
+	manifests, err := GetManifestPath()
+	if err != nil {
+		logger.Error("failed to get manifest path", "error", err)
 		return nil, err
 	}
 
-	fmt.Printf("Searching for manifests in: %s\n", manifests)
+	logger.Debug("searching for manifests", slog.String("path", manifests))
 	matches, err := filepath.Glob(filepath.Join(manifests, "*", "*", "*", "*"))
 	if err != nil {
-		fmt.Printf("Error globbing manifest files: %v\n", err)
+		logger.Error("failed to glob manifest files", "error", err)
 		return nil, err
 	}
 
 	ms := make(map[model.Name]*Manifest)
-	fmt.Printf("Found %d potential manifest files\n", len(matches))
+	logger.Debug("found potential manifest files", slog.Int("count", len(matches)))
 
 	for _, match := range matches {
-		fmt.Printf("Processing manifest candidate: %s\n", match)
+		logger.Debug("processing manifest candidate", slog.String("path", match))
 		fi, err := os.Stat(match)
 		if err != nil {
-			fmt.Printf("Error stating file: %v\n", err)
+			logger.Error("failed to stat file", "error", err)
 			return nil, err
 		}
 
@@ -181,41 +375,82 @@ func Manifests(continueOnError bool) (map[model.Name]*Manifest, error) {
 			rel, err := filepath.Rel(manifests, match)
 			if err != nil {
 				if !continueOnError {
-					fmt.Printf("Error getting relative path: %v\n", err)
+					logger.Error("failed to get relative path", "error", err)
 					return nil, fmt.Errorf("%s %w", match, err)
 				}
-				slog.Warn("bad filepath", "path", match, "error", err)
-				fmt.Printf("Skipping bad filepath: %s (error: %v)\n", match, err)
+				logger.Warn("bad filepath", "path", match, "error", err)
+				if invalid != nil {
+					invalid(model.Name{}, err)
+				}
 				continue
 			}
 
 			n := model.ParseNameFromFilepath(rel)
 			if !n.IsValid() {
 				if !continueOnError {
-					fmt.Printf("Invalid manifest name: %s\n", rel)
-					return nil, fmt.Errorf("%s %w", rel, err)
+					logger.Error("invalid manifest name", slog.String("path", rel))
+					return nil, fmt.Errorf("invalid manifest name: %s", rel)
+				}
+				logger.Warn("bad manifest name", "path", rel)
+				if invalid != nil {
+					invalid(n, fmt.Errorf("invalid manifest name: %s", rel))
 				}
-				slog.Warn("bad manifest name", "path", rel)
-				fmt.Printf("Skipping invalid manifest name: %s\n", rel)
 				continue
 			}
 
 			m, err := ParseNamedManifest(n)
 			if err != nil {
 				if !continueOnError {
-					fmt.Printf("Error parsing manifest: %v\n", err)
+					logger.Error("failed to parse manifest", "error", err)
 					return nil, fmt.Errorf("%s %w", n, err)
 				}
-				slog.Warn("bad manifest", "name", n, "error", err)
-				fmt.Printf("Skipping bad manifest %s (error: %v)\n", n, err)
+				logger.Warn("bad manifest", "name", n, "error", err)
+				if invalid != nil {
+					invalid(n, err)
+				}
 				continue
 			}
 
-			fmt.Printf("Adding manifest to results: %s\n", n)
+			logger.Debug("adding manifest to results", slog.String("name", n.String()))
 			ms[n] = m
 		}
 	}
 
-	fmt.Printf("Found %d valid manifests\n", len(ms))
+	logger.Debug("found valid manifests", slog.Int("count", len(ms)))
+	return ms, nil
+}
+
+// VerifyManifests calls Manifests and then re-hashes every layer blob (via
+// Manifest.Verify) of each result, dropping any manifest whose blobs no
+// longer match their recorded digests -- the same continueOnError/onInvalid
+// reporting Manifests uses applies to verification failures too. This is the
+// expensive, opt-in path for callers that need to detect on-disk blob
+// corruption, such as `ollama list --verify`; most callers should call
+// Manifests instead.
+func VerifyManifests(continueOnError bool, onInvalid ...func(name model.Name, err error)) (map[model.Name]*Manifest, error) {
+	var invalid func(name model.Name, err error)
+	if len(onInvalid) > 0 {
+		invalid = onInvalid[0]
+	}
+
+	ms, err := Manifests(continueOnError, onInvalid...)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, m := range ms {
+		if err := m.Verify(); err != nil {
+			if !continueOnError {
+				logger.Error("manifest failed verification", "error", err)
+				return nil, fmt.Errorf("%s %w", n, err)
+			}
+			logger.Warn("manifest failed verification", "name", n, "error", err)
+			if invalid != nil {
+				invalid(n, err)
+			}
+			delete(ms, n)
+		}
+	}
+
 	return ms, nil
 }
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ollama/ollama/types/model"
+)
+
+// writeTestBlob writes content to the blobs directory under its sha256
+// digest, returning the digest so callers can reference it from a Layer.
+func writeTestBlob(t *testing.T, content []byte) string {
+	t.Helper()
+
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	blob, err := GetBlobsPath(digest)
+	if err != nil {
+		t.Fatalf("GetBlobsPath(%q) error = %v", digest, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(blob), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(blob, content, 0o644); err != nil {
+		t.Fatalf("WriteFile(%q) error = %v", blob, err)
+	}
+
+	return digest
+}
+
+func TestManifestWriteParseRoundTrip(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	config := Layer{Digest: writeTestBlob(t, []byte("config")), Size: 6}
+	layer := Layer{Digest: writeTestBlob(t, []byte("layer")), Size: 5}
+	name := model.ParseName("library/roundtrip:latest")
+
+	if err := WriteManifest(name, config, []Layer{layer}); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	got, err := ParseNamedManifest(name)
+	if err != nil {
+		t.Fatalf("ParseNamedManifest() error = %v", err)
+	}
+	if got.Config.Digest != config.Digest || len(got.Layers) != 1 || got.Layers[0].Digest != layer.Digest {
+		t.Fatalf("parsed manifest = %+v, want config %+v and layers [%+v]", got, config, layer)
+	}
+	if err := got.Verify(); err != nil {
+		t.Fatalf("Verify() error = %v, want nil for untampered blobs", err)
+	}
+}
+
+func TestManifestEncryptedWriteParseRoundTrip(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+	t.Setenv("OLLAMA_MANIFEST_KEY", "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+	config := Layer{Digest: writeTestBlob(t, []byte("config")), Size: 6}
+	name := model.ParseName("library/encrypted:latest")
+	key, err := manifestKey()
+	if err != nil {
+		t.Fatalf("manifestKey() error = %v", err)
+	}
+
+	if err := WriteEncryptedManifest(name, config, nil, key); err != nil {
+		t.Fatalf("WriteEncryptedManifest() error = %v", err)
+	}
+
+	got, err := ParseNamedManifest(name)
+	if err != nil {
+		t.Fatalf("ParseNamedManifest() error = %v", err)
+	}
+	if got.Config.Digest != config.Digest {
+		t.Fatalf("parsed manifest config digest = %q, want %q", got.Config.Digest, config.Digest)
+	}
+}
+
+func TestManifestEncryptedParseFailsWithoutKey(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+	t.Setenv("OLLAMA_MANIFEST_KEY", "101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f")
+
+	config := Layer{Digest: writeTestBlob(t, []byte("config")), Size: 6}
+	name := model.ParseName("library/nokey:latest")
+	key, err := manifestKey()
+	if err != nil {
+		t.Fatalf("manifestKey() error = %v", err)
+	}
+	if err := WriteEncryptedManifest(name, config, nil, key); err != nil {
+		t.Fatalf("WriteEncryptedManifest() error = %v", err)
+	}
+
+	t.Setenv("OLLAMA_MANIFEST_KEY", "")
+	if _, err := ParseNamedManifest(name); err == nil {
+		t.Fatal("ParseNamedManifest() with OLLAMA_MANIFEST_KEY unset succeeded, want error")
+	}
+
+	t.Setenv("OLLAMA_MANIFEST_KEY", "202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f")
+	if _, err := ParseNamedManifest(name); err == nil {
+		t.Fatal("ParseNamedManifest() with wrong OLLAMA_MANIFEST_KEY succeeded, want error")
+	}
+}
+
+func TestManifestVerifyDetectsTamperedBlob(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	config := Layer{Digest: writeTestBlob(t, []byte("config")), Size: 6}
+	layer := Layer{Digest: writeTestBlob(t, []byte("layer")), Size: 5}
+	name := model.ParseName("library/tampered:latest")
+	if err := WriteManifest(name, config, []Layer{layer}); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	m, err := ParseNamedManifest(name)
+	if err != nil {
+		t.Fatalf("ParseNamedManifest() error = %v", err)
+	}
+
+	blob, err := GetBlobsPath(layer.Digest)
+	if err != nil {
+		t.Fatalf("GetBlobsPath() error = %v", err)
+	}
+	if err := os.WriteFile(blob, []byte("tampered contents"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	err = m.Verify()
+	var mismatch *DigestMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("Verify() error = %v, want *DigestMismatchError", err)
+	}
+	if mismatch.Digest != layer.Digest {
+		t.Fatalf("DigestMismatchError.Digest = %q, want %q", mismatch.Digest, layer.Digest)
+	}
+}
+
+func TestManifestsContinueOnError(t *testing.T) {
+	t.Setenv("OLLAMA_MODELS", t.TempDir())
+
+	config := Layer{Digest: writeTestBlob(t, []byte("config")), Size: 6}
+	good := model.ParseName("library/good:latest")
+	if err := WriteManifest(good, config, nil); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+
+	bad := model.ParseName("library/bad:latest")
+	if err := WriteManifest(bad, config, nil); err != nil {
+		t.Fatalf("WriteManifest() error = %v", err)
+	}
+	badPath, err := GetManifestPath()
+	if err != nil {
+		t.Fatalf("GetManifestPath() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(badPath, bad.Filepath()), []byte("not json"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var invalid []model.Name
+	ms, err := Manifests(true, func(name model.Name, _ error) {
+		invalid = append(invalid, name)
+	})
+	if err != nil {
+		t.Fatalf("Manifests(true) error = %v", err)
+	}
+	if _, ok := ms[good]; !ok {
+		t.Fatalf("Manifests() = %v, want %q present", ms, good)
+	}
+	if len(invalid) != 1 || invalid[0] != bad {
+		t.Fatalf("onInvalid calls = %v, want exactly [%q]", invalid, bad)
+	}
+
+	if _, err := Manifests(false); err == nil {
+		t.Fatal("Manifests(false) with a corrupt manifest present succeeded, want error")
+	}
+}
+
+func TestDigestMismatchError(t *testing.T) {
+	err := &DigestMismatchError{Digest: "sha256:want", Actual: "sha256:got"}
+
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Error("errors.Is(err, ErrDigestMismatch) = false, want true")
+	}
+
+	const want = "digest mismatch for sha256:want: blob hashes to sha256:got"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}